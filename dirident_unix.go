@@ -0,0 +1,19 @@
+//go:build linux || darwin || freebsd || openbsd || netbsd
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// dirIdentity returns a key uniquely identifying a directory's underlying
+// device+inode, used to detect symlink cycles during a recursive walk.
+func dirIdentity(resolvedPath string, info os.FileInfo) (string, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%d:%d", stat.Dev, stat.Ino), true
+}
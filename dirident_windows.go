@@ -0,0 +1,12 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// dirIdentity has no portable inode equivalent on Windows, so the path the
+// caller passes in (the symlink target, for a symlinked directory) is used as
+// the cycle-detection key instead; it is not made absolute.
+func dirIdentity(resolvedPath string, info os.FileInfo) (string, bool) {
+	return resolvedPath, true
+}
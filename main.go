@@ -3,14 +3,19 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"math/rand"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -25,6 +30,11 @@ type Config struct {
 		FileExtensionPattern string `json:"file_extension_pattern"`
 		PostURL           string   `json:"post_url"`
 		AuthenticationHeader string `json:"authentication_header"`
+		SpoolDir          string   `json:"spool_dir"`
+		Workers           int      `json:"workers"`
+		MetricsAddr       string   `json:"metrics_addr"`
+		FollowSymlinks    bool     `json:"follow_symlinks"`
+		IgnorePatterns    []string `json:"ignore_patterns"`
 	} `json:"FileWatcher"`
 }
 
@@ -35,6 +45,45 @@ type EventPayload struct {
 	EventID  string `json:"event_id"`
 }
 
+// Retry/backoff tuning for webhook delivery: exponential backoff with
+// jitter, capped attempts, dropping into the dead-letter count thereafter.
+const (
+	retryBaseDelay   = 500 * time.Millisecond
+	retryFactor      = 2
+	retryMaxDelay    = 60 * time.Second
+	retryMaxAttempts = 8
+
+	defaultSpoolDir = "spool"
+	defaultWorkers  = 4
+)
+
+// outboxEntry is the on-disk, spooled form of a pending delivery: the
+// payload plus everything needed to retry it without the original Watcher.
+type outboxEntry struct {
+	Payload EventPayload      `json:"payload"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Attempt int               `json:"attempt"`
+}
+
+// deliveryCounters are the /metrics counters, updated atomically from the
+// worker pool.
+type deliveryCounters struct {
+	delivered    uint64
+	retrying     uint64
+	deadLettered uint64
+}
+
+// permanentDeliveryError marks a delivery failure as non-retryable (e.g. a
+// 4xx response), so the worker dead-letters it immediately instead of
+// retrying with backoff.
+type permanentDeliveryError struct {
+	err error
+}
+
+func (e *permanentDeliveryError) Error() string { return e.err.Error() }
+func (e *permanentDeliveryError) Unwrap() error { return e.err }
+
 // Watcher handles file system monitoring
 type Watcher struct {
 	watcher       *fsnotify.Watcher
@@ -42,6 +91,14 @@ type Watcher struct {
 	lastEventTime map[string]time.Time
 	mutex         sync.Mutex
 	debounceTime  time.Duration
+
+	spoolDir string
+	workers  int
+	jobs     chan string
+	counters deliveryCounters
+
+	dirsMutex   sync.Mutex
+	watchedDirs map[string]struct{}
 }
 
 // NewWatcher creates a new file watcher
@@ -51,12 +108,26 @@ func NewWatcher(config *Config) (*Watcher, error) {
 		return nil, err
 	}
 
+	spoolDir := config.FileWatcher.SpoolDir
+	if spoolDir == "" {
+		spoolDir = defaultSpoolDir
+	}
+
+	workers := config.FileWatcher.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
 	return &Watcher{
 		watcher:       fsWatcher,
 		config:        config,
 		lastEventTime: make(map[string]time.Time),
 		mutex:         sync.Mutex{},
 		debounceTime:  1 * time.Second,
+		spoolDir:      spoolDir,
+		workers:       workers,
+		jobs:          make(chan string, 256),
+		watchedDirs:   make(map[string]struct{}),
 	}, nil
 }
 
@@ -101,60 +172,237 @@ func (w *Watcher) shouldProcessEvent(path string) bool {
 	return false
 }
 
-// postEvent sends the event information to the configured webhook
-func (w *Watcher) postEvent(path string, eventID string) {
+// enqueueEvent builds the webhook payload for a detected file event, spools
+// it to disk, and hands it to the worker pool for delivery.
+func (w *Watcher) enqueueEvent(path, eventID string) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		log.Printf("🚩 Error getting absolute path: %v", err)
 		return
 	}
-	
+
 	dir, file := filepath.Split(absPath)
-	
-	payload := EventPayload{
-		Filepath: dir,
-		Filename: file,
-		EventID:  eventID,
+	payload := EventPayload{Filepath: dir, Filename: file, EventID: eventID}
+
+	entry := &outboxEntry{
+		Payload: payload,
+		URL:     w.config.FileWatcher.PostURL,
+		Headers: map[string]string{
+			"Authorization": w.config.FileWatcher.AuthenticationHeader,
+			"Content-Type":  "application/json",
+			"Accept":        "application/json",
+		},
+		Attempt: 0,
 	}
-	
-	payloadBytes, err := json.Marshal(payload)
+
+	spoolPath, err := w.spoolEntry(entry)
 	if err != nil {
-		log.Printf("🚩 Error marshaling JSON: %v", err)
+		log.Printf("🚩 Error spooling event %s: %v", eventID, err)
 		return
 	}
-	
-	req, err := http.NewRequest("POST", w.config.FileWatcher.PostURL, bytes.NewBuffer(payloadBytes))
+
+	w.jobs <- spoolPath
+}
+
+// spoolEntry writes entry to spool_dir as "<unix_nanos>-<event_id>.json" so
+// it survives a process restart until delivery succeeds.
+func (w *Watcher) spoolEntry(entry *outboxEntry) (string, error) {
+	name := fmt.Sprintf("%d-%s.json", time.Now().UnixNano(), entry.Payload.EventID)
+	path := filepath.Join(w.spoolDir, name)
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// loadSpoolEntry reads back a spooled outboxEntry.
+func (w *Watcher) loadSpoolEntry(path string) (*outboxEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry outboxEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// saveSpoolEntry rewrites a spooled entry in place, used to persist the
+// incremented attempt count between retries.
+func (w *Watcher) saveSpoolEntry(path string, entry *outboxEntry) error {
+	data, err := json.Marshal(entry)
 	if err != nil {
-		log.Printf("🚩 Error creating request: %v", err)
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// recoverOutbox re-enqueues any spool files left over from a previous run,
+// in filename (chronological) order, so events survive process restarts and
+// receiver downtime.
+func (w *Watcher) recoverOutbox() error {
+	dirEntries, err := os.ReadDir(w.spoolDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var names []string
+	for _, e := range dirEntries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		log.Printf("📤 Re-enqueuing spooled event from %s", name)
+		w.jobs <- filepath.Join(w.spoolDir, name)
+	}
+	return nil
+}
+
+// startWorkers launches the bounded worker pool that drains w.jobs.
+func (w *Watcher) startWorkers() {
+	for i := 0; i < w.workers; i++ {
+		go w.worker()
+	}
+}
+
+// worker delivers spooled events one at a time until w.jobs is closed.
+func (w *Watcher) worker() {
+	for spoolPath := range w.jobs {
+		w.deliver(spoolPath)
+	}
+}
+
+// deliver retries a spooled event with exponential backoff and jitter until
+// it succeeds, is permanently rejected, or exhausts retryMaxAttempts.
+func (w *Watcher) deliver(spoolPath string) {
+	entry, err := w.loadSpoolEntry(spoolPath)
+	if err != nil {
+		log.Printf("🚩 Error loading spooled event %s: %v", spoolPath, err)
+		os.Remove(spoolPath)
 		return
 	}
-	
-	req.Header.Set("Authorization", w.config.FileWatcher.AuthenticationHeader)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	
-	log.Printf("↔️ Sending HTTP POST request for event %s: %s", eventID, absPath)
-	
+
+	for {
+		err := w.attemptDelivery(entry)
+		if err == nil {
+			atomic.AddUint64(&w.counters.delivered, 1)
+			if err := os.Remove(spoolPath); err != nil {
+				log.Printf("🚩 Error removing delivered spool file %s: %v", spoolPath, err)
+			}
+			log.Printf("✅ Successfully posted file info for event %s: %s, %s", entry.Payload.EventID, entry.Payload.Filepath, entry.Payload.Filename)
+			return
+		}
+
+		var permErr *permanentDeliveryError
+		if errors.As(err, &permErr) {
+			log.Printf("🚩 Permanent error delivering event %s, dead-lettering %s: %v", entry.Payload.EventID, spoolPath, err)
+			atomic.AddUint64(&w.counters.deadLettered, 1)
+			return
+		}
+
+		delay := backoffDelay(entry.Attempt)
+		entry.Attempt++
+		if entry.Attempt >= retryMaxAttempts {
+			log.Printf("🚩 Giving up on event %s after %d attempts, dead-lettering %s: %v", entry.Payload.EventID, entry.Attempt, spoolPath, err)
+			atomic.AddUint64(&w.counters.deadLettered, 1)
+			return
+		}
+
+		atomic.AddUint64(&w.counters.retrying, 1)
+		log.Printf("🚩 Retrying event %s in %s (attempt %d): %v", entry.Payload.EventID, delay, entry.Attempt+1, err)
+
+		if err := w.saveSpoolEntry(spoolPath, entry); err != nil {
+			log.Printf("🚩 Error persisting retry state for %s: %v", spoolPath, err)
+		}
+
+		time.Sleep(delay)
+	}
+}
+
+// backoffDelay computes the exponential backoff with jitter for a given
+// (already-failed) attempt count: base 500ms, factor 2, capped at 60s.
+func backoffDelay(attempt int) time.Duration {
+	delay := retryBaseDelay
+	for i := 0; i < attempt; i++ {
+		delay *= retryFactor
+		if delay >= retryMaxDelay {
+			delay = retryMaxDelay
+			break
+		}
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay)/2 + 1))
+	return delay/2 + jitter
+}
+
+// attemptDelivery makes a single HTTP POST attempt. Network errors and
+// 5xx/429 responses are returned as plain (retryable) errors; any other
+// non-200 response is wrapped as a permanentDeliveryError.
+func (w *Watcher) attemptDelivery(entry *outboxEntry) error {
+	payloadBytes, err := json.Marshal(entry.Payload)
+	if err != nil {
+		return &permanentDeliveryError{err: fmt.Errorf("error marshaling JSON: %w", err)}
+	}
+
+	req, err := http.NewRequest("POST", entry.URL, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return &permanentDeliveryError{err: fmt.Errorf("error creating request: %w", err)}
+	}
+	for k, v := range entry.Headers {
+		req.Header.Set(k, v)
+	}
+
+	log.Printf("↔️ Sending HTTP POST request for event %s: %s%s", entry.Payload.EventID, entry.Payload.Filepath, entry.Payload.Filename)
+
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("🚩 Error sending POST request for event %s: %v", eventID, err)
-		return
+		return fmt.Errorf("error sending POST request: %w", err)
 	}
 	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		log.Printf("🚩 Error in HTTP POST request for event %s: %d - %s", eventID, resp.StatusCode, string(body))
-		return
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
 	}
-	
-	log.Printf("✅ Successfully posted file info for event %s: %s, %s", eventID, dir, file)
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("receiver error %d: %s", resp.StatusCode, string(body))
+	}
+	return &permanentDeliveryError{err: fmt.Errorf("receiver rejected event: %d - %s", resp.StatusCode, string(body))}
+}
+
+// startMetricsServer exposes delivery counters on metricsAddr/metrics, if
+// configured.
+func (w *Watcher) startMetricsServer(metricsAddr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(rw http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(rw, "delivered %d\n", atomic.LoadUint64(&w.counters.delivered))
+		fmt.Fprintf(rw, "retrying %d\n", atomic.LoadUint64(&w.counters.retrying))
+		fmt.Fprintf(rw, "dead_lettered %d\n", atomic.LoadUint64(&w.counters.deadLettered))
+	})
+
+	log.Printf("📊 Serving delivery metrics on %s/metrics", metricsAddr)
+	go func() {
+		if err := http.ListenAndServe(metricsAddr, mux); err != nil {
+			log.Printf("🚩 Metrics server error: %v", err)
+		}
+	}()
 }
 
 // processEvent handles a file system event
 func (w *Watcher) processEvent(event fsnotify.Event) {
-	// Skip directories and non-matching files
 	fileInfo, err := os.Stat(event.Name)
 	if err != nil {
 		// File might have been deleted
@@ -163,11 +411,16 @@ func (w *Watcher) processEvent(event fsnotify.Event) {
 		}
 		return
 	}
-	
-	if fileInfo.IsDir() || !w.matchesPattern(event.Name) {
+
+	if fileInfo.IsDir() {
+		w.handleDirectoryEvent(event)
 		return
 	}
-	
+
+	if !w.matchesPattern(event.Name) {
+		return
+	}
+
 	// Convert fsnotify event to our event type
 	var eventType string
 	switch {
@@ -196,32 +449,147 @@ func (w *Watcher) processEvent(event fsnotify.Event) {
 	// Generate event ID
 	eventID := uuid.New().String()
 	log.Printf("🚥 Detected event %s (%s) for file: %s", eventID, eventType, event.Name)
-	
-	// Process event in a goroutine
-	go w.postEvent(event.Name, eventID)
+
+	w.enqueueEvent(event.Name, eventID)
+}
+
+// handleDirectoryEvent watches newly created subdirectories (and their
+// future children) as soon as they appear, since addDirectoryRecursively
+// otherwise only runs once at Start. A "directories" event is only emitted
+// if it's in the configured event_types.
+func (w *Watcher) handleDirectoryEvent(event fsnotify.Event) {
+	if event.Op&fsnotify.Create != fsnotify.Create {
+		return
+	}
+
+	if w.shouldIgnore(event.Name) {
+		return
+	}
+
+	if err := w.addDirectoryRecursively(event.Name); err != nil {
+		log.Printf("Error watching new directory %s: %v", event.Name, err)
+	}
+
+	if !w.isEventTypeWatched("directories") {
+		return
+	}
+
+	eventID := uuid.New().String()
+	log.Printf("🚥 Detected event %s (directories) for file: %s", eventID, event.Name)
+	w.enqueueEvent(event.Name, eventID)
+}
+
+// shouldIgnore reports whether path matches one of the configured
+// ignore_patterns, checked against both the basename and the full path.
+func (w *Watcher) shouldIgnore(path string) bool {
+	base := filepath.Base(path)
+	for _, pattern := range w.config.FileWatcher.IgnorePatterns {
+		if matched, _ := filepath.Match(pattern, base); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, path); matched {
+			return true
+		}
+	}
+	return false
 }
 
-// addDirectoryRecursively adds a directory and all its subdirectories to the watcher
+// watchDir adds resolvedPath to the fsnotify watcher, skipping it if it (or
+// the symlink target it resolves to) is already being watched.
+func (w *Watcher) watchDir(resolvedPath, displayPath string) error {
+	w.dirsMutex.Lock()
+	if _, already := w.watchedDirs[resolvedPath]; already {
+		w.dirsMutex.Unlock()
+		return nil
+	}
+	w.watchedDirs[resolvedPath] = struct{}{}
+	w.dirsMutex.Unlock()
+
+	if err := w.watcher.Add(resolvedPath); err != nil {
+		log.Printf("Error watching directory %s: %v", displayPath, err)
+		return err
+	}
+	log.Printf("Watching directory: %s", displayPath)
+	return nil
+}
+
+// addDirectoryRecursively adds a directory and all its subdirectories to the
+// watcher. If follow_symlinks is enabled, symlinked directories are resolved
+// and walked too, with seen tracking visited inodes (or, on platforms
+// without one, resolved paths) to avoid infinite symlink cycles.
 func (w *Watcher) addDirectoryRecursively(path string) error {
-	return filepath.Walk(path, func(walkPath string, info os.FileInfo, err error) error {
+	return w.addDirTree(path, make(map[string]struct{}))
+}
+
+func (w *Watcher) addDirTree(path string, seen map[string]struct{}) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	resolved := path
+	if info.Mode()&os.ModeSymlink != 0 {
+		if !w.config.FileWatcher.FollowSymlinks {
+			return nil
+		}
+		resolved, err = filepath.EvalSymlinks(path)
 		if err != nil {
 			return err
 		}
-		
-		if info.IsDir() {
-			if err := w.watcher.Add(walkPath); err != nil {
-				log.Printf("Error watching directory %s: %v", walkPath, err)
-				return err
-			}
-			log.Printf("Watching directory: %s", walkPath)
+		if info, err = os.Stat(resolved); err != nil {
+			return err
 		}
-		
+	}
+
+	if !info.IsDir() {
 		return nil
-	})
+	}
+
+	if w.shouldIgnore(path) {
+		log.Printf("Ignoring directory: %s", path)
+		return nil
+	}
+
+	if key, ok := dirIdentity(resolved, info); ok {
+		if _, cyclic := seen[key]; cyclic {
+			log.Printf("Skipping %s: symlink cycle detected", path)
+			return nil
+		}
+		seen[key] = struct{}{}
+	}
+
+	if err := w.watchDir(resolved, path); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(resolved)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := w.addDirTree(filepath.Join(path, entry.Name()), seen); err != nil {
+			log.Printf("Error processing %s: %v", filepath.Join(path, entry.Name()), err)
+		}
+	}
+	return nil
 }
 
 // Start begins watching the configured directories
 func (w *Watcher) Start() error {
+	if err := os.MkdirAll(w.spoolDir, 0755); err != nil {
+		return fmt.Errorf("error creating spool directory %s: %w", w.spoolDir, err)
+	}
+
+	w.startWorkers()
+
+	if err := w.recoverOutbox(); err != nil {
+		return fmt.Errorf("error recovering outbox from %s: %w", w.spoolDir, err)
+	}
+
+	if w.config.FileWatcher.MetricsAddr != "" {
+		w.startMetricsServer(w.config.FileWatcher.MetricsAddr)
+	}
+
 	// Add all directories to the watcher
 	for _, dir := range w.config.FileWatcher.Directories {
 		expandedDir := os.ExpandEnv(dir) // Expand environment variables in path
@@ -260,9 +628,10 @@ func (w *Watcher) Start() error {
 	return nil
 }
 
-// Stop closes the watcher
+// Stop closes the watcher and worker pool
 func (w *Watcher) Stop() {
 	w.watcher.Close()
+	close(w.jobs)
 }
 
 // loadConfig loads the configuration from a file
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt int
+		minMs   int64
+		maxMs   int64
+	}{
+		{"first retry uses the 500ms base", 0, 250, 500},
+		{"second retry doubles", 1, 500, 1000},
+		{"third retry doubles again", 2, 1000, 2000},
+		{"large attempt counts cap at 60s", 20, 30000, 60000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay := backoffDelay(tt.attempt)
+			min := time.Duration(tt.minMs) * time.Millisecond
+			max := time.Duration(tt.maxMs) * time.Millisecond
+			if delay < min || delay > max {
+				t.Errorf("backoffDelay(%d) = %s, want between %s and %s", tt.attempt, delay, min, max)
+			}
+		})
+	}
+}
+
+func TestShouldIgnore(t *testing.T) {
+	w := &Watcher{config: &Config{}}
+	w.config.FileWatcher.IgnorePatterns = []string{"*.tmp", "node_modules", "/var/log/*"}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/home/user/project/data.tmp", true},
+		{"/home/user/project/node_modules", true},
+		{"/home/user/project/src/node_modules", true},
+		{"/var/log/app.log", true},
+		{"/home/user/project/main.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := w.shouldIgnore(tt.path); got != tt.want {
+			t.Errorf("shouldIgnore(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAddDirTreeSymlinkCycle(t *testing.T) {
+	root := t.TempDir()
+	child := filepath.Join(root, "child")
+	if err := os.Mkdir(child, 0755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	if err := os.Symlink(root, filepath.Join(child, "back-to-root")); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatalf("fsnotify.NewWatcher: %v", err)
+	}
+	defer fsWatcher.Close()
+
+	w := &Watcher{
+		watcher:     fsWatcher,
+		config:      &Config{},
+		watchedDirs: make(map[string]struct{}),
+	}
+	w.config.FileWatcher.FollowSymlinks = true
+
+	done := make(chan error, 1)
+	go func() { done <- w.addDirectoryRecursively(root) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("addDirectoryRecursively: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("addDirectoryRecursively did not return, symlink cycle was not broken")
+	}
+
+	if len(w.watchedDirs) != 2 {
+		t.Errorf("watchedDirs = %d entries, want 2 (root and child, cycle back to root skipped)", len(w.watchedDirs))
+	}
+}
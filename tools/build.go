@@ -2,30 +2,208 @@
 package main
 
 import (
+	"archive/tar"
 	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
 )
 
-// Supported platforms for cross-compilation
-var platforms = []struct {
-	os   string
-	arch string
-}{
-	{"windows", "amd64"},
-	{"windows", "386"},
-	{"darwin", "amd64"},
-	{"darwin", "arm64"},
-	{"linux", "amd64"},
-	{"linux", "386"},
-	{"linux", "arm"},
-	{"linux", "arm64"},
+// buildJSONPath is the optional manifest used to add or override
+// cross-compilation targets without editing this file.
+const buildJSONPath = "build.json"
+
+// archiveFile is a companion file bundled into a target's release archive
+// alongside the binary, e.g. a systemd unit or launchd plist.
+type archiveFile struct {
+	Src  string      `json:"src"`
+	Dst  string      `json:"dst,omitempty"`
+	Perm os.FileMode `json:"perm,omitempty"`
+}
+
+// target describes one cross-compilation target: how to build it and what
+// extra files its release archive should carry.
+type target struct {
+	Name         string        `json:"name,omitempty"`
+	GOOS         string        `json:"goos"`
+	GOARCH       string        `json:"goarch"`
+	GOARM        string        `json:"goarm,omitempty"`
+	CGOEnabled   bool          `json:"cgo_enabled,omitempty"`
+	BuildTags    []string      `json:"build_tags,omitempty"`
+	LDFlagsExtra []string      `json:"ldflags_extra,omitempty"`
+	ArchiveFiles []archiveFile `json:"archive_files,omitempty"`
+}
+
+// platformName is the directory/archive-naming identifier for a target,
+// e.g. "linux-arm64" or "linux-arm-6".
+func (t target) platformName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	name := fmt.Sprintf("%s-%s", t.GOOS, t.GOARCH)
+	if t.GOARM != "" {
+		name += "-" + t.GOARM
+	}
+	return name
+}
+
+// defaultTargets is the built-in target list used when build.json is absent.
+func defaultTargets() []target {
+	return []target{
+		{GOOS: "windows", GOARCH: "amd64", ArchiveFiles: []archiveFile{{Src: "packaging/windows/filewatcher.reg"}}},
+		{GOOS: "windows", GOARCH: "386", ArchiveFiles: []archiveFile{{Src: "packaging/windows/filewatcher.reg"}}},
+		{GOOS: "darwin", GOARCH: "amd64", ArchiveFiles: []archiveFile{{Src: "packaging/darwin/com.filewatcher.plist"}}},
+		{GOOS: "darwin", GOARCH: "arm64", ArchiveFiles: []archiveFile{{Src: "packaging/darwin/com.filewatcher.plist"}}},
+		{GOOS: "linux", GOARCH: "amd64", ArchiveFiles: []archiveFile{{Src: "packaging/linux/filewatcher.service"}}},
+		{GOOS: "linux", GOARCH: "386"},
+		{GOOS: "linux", GOARCH: "arm", ArchiveFiles: []archiveFile{{Src: "packaging/linux/filewatcher.service"}}},
+		{GOOS: "linux", GOARCH: "arm", GOARM: "6"},
+		{GOOS: "linux", GOARCH: "arm", GOARM: "7"},
+		{GOOS: "linux", GOARCH: "arm64", ArchiveFiles: []archiveFile{{Src: "packaging/linux/filewatcher.service"}}},
+		{GOOS: "linux", GOARCH: "riscv64"},
+		{GOOS: "freebsd", GOARCH: "amd64"},
+		{GOOS: "openbsd", GOARCH: "amd64"},
+	}
+}
+
+// loadTargets reads the target list from path if it exists (either a bare
+// JSON array of targets, or an object with a "targets" key), falling back to
+// defaultTargets() when the manifest is absent.
+func loadTargets(path string) ([]target, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return defaultTargets(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapped struct {
+		Targets []target `json:"targets"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err == nil && len(wrapped.Targets) > 0 {
+		return wrapped.Targets, nil
+	}
+
+	var targets []target
+	if err := json.Unmarshal(data, &targets); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return targets, nil
+}
+
+// targetKey is the "goos/goarch[/goarm]" identifier used to match a target
+// against the -targets flag, e.g. "linux/arm64" or "linux/arm/6".
+func targetKey(t target) string {
+	key := fmt.Sprintf("%s/%s", t.GOOS, t.GOARCH)
+	if t.GOARM != "" {
+		key += "/" + t.GOARM
+	}
+	return key
+}
+
+// selectTargets filters targets to those whose targetKey matches the
+// comma-separated -targets list, e.g. "linux/amd64,linux/arm/6,darwin/arm64".
+// GOARM must be given explicitly to select an arm variant, since
+// defaultTargets() lists plain "linux/arm" alongside "linux/arm/6" and
+// "linux/arm/7" as distinct targets. An empty csv selects everything.
+func selectTargets(all []target, csv string) ([]target, error) {
+	if csv == "" {
+		return all, nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, pair := range strings.Split(csv, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair != "" {
+			wanted[pair] = true
+		}
+	}
+
+	var selected []target
+	for _, t := range all {
+		if wanted[targetKey(t)] {
+			selected = append(selected, t)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no targets matched -targets %q", csv)
+	}
+	return selected, nil
+}
+
+// currentTarget returns the target matching the running GOOS/GOARCH from
+// all, falling back to a plain CGO-enabled target if none is listed.
+func currentTarget(all []target) target {
+	for _, t := range all {
+		if t.GOOS == runtime.GOOS && t.GOARCH == runtime.GOARCH && t.GOARM == "" {
+			return t
+		}
+	}
+	return target{GOOS: runtime.GOOS, GOARCH: runtime.GOARCH, CGOEnabled: true}
+}
+
+// buildResult pairs a built binary with the target it was built for, so
+// later stages (archiving) can see the target's ArchiveFiles.
+type buildResult struct {
+	target     target
+	binaryPath string
+}
+
+// runBuilds builds every target, optionally in parallel (-parallel N),
+// skipping (and logging) any that fail rather than aborting the whole run.
+func runBuilds(targets []target, outputDir, packageName, version string, parallel int) []buildResult {
+	if parallel <= 1 {
+		var results []buildResult
+		for _, t := range targets {
+			binaryPath, err := buildBinary(t, outputDir, packageName, version)
+			if err != nil {
+				fmt.Printf("Error building for %s: %v\n", t.platformName(), err)
+				continue
+			}
+			results = append(results, buildResult{target: t, binaryPath: binaryPath})
+		}
+		return results
+	}
+
+	var (
+		g       errgroup.Group
+		mu      sync.Mutex
+		results []buildResult
+	)
+	g.SetLimit(parallel)
+	for _, t := range targets {
+		t := t
+		g.Go(func() error {
+			binaryPath, err := buildBinary(t, outputDir, packageName, version)
+			if err != nil {
+				fmt.Printf("Error building for %s: %v\n", t.platformName(), err)
+				return nil
+			}
+			mu.Lock()
+			results = append(results, buildResult{target: t, binaryPath: binaryPath})
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return results
 }
 
 func main() {
@@ -33,9 +211,33 @@ func main() {
 	outputDir := flag.String("output", "dist", "Output directory for binaries")
 	version := flag.String("version", "1.0.0", "Version number for the build")
 	onlyCurrentPlatform := flag.Bool("current", false, "Build only for the current platform")
-	createReleaseArchives := flag.Bool("release", false, "Create release-ready zip archives")
+	createReleaseArchives := flag.Bool("release", false, "Create release-ready archives")
+	format := flag.String("format", "auto", "Release archive format: auto, zip, tar.gz, or both")
+	sign := flag.Bool("sign", false, "GPG detach-sign each release archive (.asc) if gpg is in PATH")
+	upload := flag.Bool("upload", false, "Upload the archives in -output/release to GitHub Releases instead of building")
+	githubRepo := flag.String("github-repo", "", "owner/name of the GitHub repository to upload to (required with -upload)")
+	githubToken := flag.String("github-token", "", "GitHub API token; defaults to the GITHUB_TOKEN env var")
+	tag := flag.String("tag", "", "Release tag to create or reuse (required with -upload)")
+	publish := flag.Bool("publish", false, "Flip the release to draft:false after uploading")
+	targetsFlag := flag.String("targets", "", "Comma-separated goos/arch[/goarm] list to build, e.g. linux/amd64,linux/arm/6 (default: all)")
+	parallel := flag.Int("parallel", 1, "Number of targets to build concurrently")
 	flag.Parse()
 
+	if err := validateFormat(*format); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *upload {
+		releaseDir := filepath.Join(*outputDir, "release")
+		if err := runUpload(releaseDir, *githubRepo, *githubToken, *tag, *version, *publish); err != nil {
+			fmt.Printf("Error uploading release: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Upload completed successfully!")
+		return
+	}
+
 	// Create output directory if it doesn't exist
 	if err := os.MkdirAll(*outputDir, 0755); err != nil {
 		fmt.Printf("Error creating output directory: %v\n", err)
@@ -49,26 +251,30 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Build for specified platforms
-	var builtBinaries []string
+	allTargets, err := loadTargets(buildJSONPath)
+	if err != nil {
+		fmt.Printf("Error loading %s: %v\n", buildJSONPath, err)
+		os.Exit(1)
+	}
+
+	// Build for specified targets
+	var results []buildResult
 	if *onlyCurrentPlatform {
 		// Build only for current platform
-		binaryPath, err := buildBinary(runtime.GOOS, runtime.GOARCH, *outputDir, packageName, *version)
+		t := currentTarget(allTargets)
+		binaryPath, err := buildBinary(t, *outputDir, packageName, *version)
 		if err != nil {
 			fmt.Printf("Error building for current platform: %v\n", err)
 			os.Exit(1)
 		}
-		builtBinaries = append(builtBinaries, binaryPath)
+		results = append(results, buildResult{target: t, binaryPath: binaryPath})
 	} else {
-		// Build for all supported platforms
-		for _, platform := range platforms {
-			binaryPath, err := buildBinary(platform.os, platform.arch, *outputDir, packageName, *version)
-			if err != nil {
-				fmt.Printf("Error building for %s/%s: %v\n", platform.os, platform.arch, err)
-				continue
-			}
-			builtBinaries = append(builtBinaries, binaryPath)
+		selected, err := selectTargets(allTargets, *targetsFlag)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
 		}
+		results = runBuilds(selected, *outputDir, packageName, *version, *parallel)
 	}
 
 	fmt.Println("Build completed successfully!")
@@ -81,21 +287,45 @@ func main() {
 			os.Exit(1)
 		}
 
-		for _, binaryPath := range builtBinaries {
-			if err := createReleaseArchive(binaryPath, releaseDir, packageName, *version); err != nil {
-				fmt.Printf("Error creating release archive for %s: %v\n", binaryPath, err)
+		var manifest []checksumEntry
+		for _, result := range results {
+			entries, err := createReleaseArchive(result, releaseDir, packageName, *version, *format)
+			if err != nil {
+				fmt.Printf("Error creating release archive for %s: %v\n", result.binaryPath, err)
 				continue
 			}
+			manifest = append(manifest, entries...)
+		}
+
+		if err := writeChecksumManifest(releaseDir, manifest); err != nil {
+			fmt.Printf("Error writing SHA256SUMS.txt: %v\n", err)
+		}
+
+		if *sign {
+			if _, err := exec.LookPath("gpg"); err != nil {
+				fmt.Printf("Warning: -sign requested but gpg was not found in PATH: %v\n", err)
+			} else {
+				for _, e := range manifest {
+					archivePath := filepath.Join(releaseDir, e.name)
+					if err := signArchive(archivePath); err != nil {
+						fmt.Printf("Warning: could not sign %s: %v\n", archivePath, err)
+					}
+				}
+			}
 		}
 
 		fmt.Printf("\nRelease archives created in %s\n", releaseDir)
-		fmt.Println("You can now upload these archives to GitHub releases:")
-		fmt.Println("1. Go to your GitHub repository")
-		fmt.Println("2. Click on 'Releases'")
-		fmt.Println("3. Click on 'Draft a new release'")
-		fmt.Println("4. Fill in the release details")
-		fmt.Println("5. Upload the zip files from the release directory")
-		fmt.Println("6. Click 'Publish release'")
+		fmt.Println("Run with -upload -github-repo owner/name -tag vX.Y.Z to publish them to GitHub Releases.")
+	}
+}
+
+// validateFormat checks that the -format flag holds a known value
+func validateFormat(format string) error {
+	switch format {
+	case "auto", "zip", "tar.gz", "both":
+		return nil
+	default:
+		return fmt.Errorf("invalid -format %q (want auto, zip, tar.gz, or both)", format)
 	}
 }
 
@@ -123,29 +353,48 @@ func getPackageName() (string, error) {
 	return filepath.Base(modulePath), nil
 }
 
-// buildBinary builds the application for a specific platform
-func buildBinary(goos, goarch, outputDir, packageName, version string) (string, error) {
+// buildBinary builds the application for a specific target
+func buildBinary(t target, outputDir, packageName, version string) (string, error) {
 	// Set binary name based on platform
 	binaryName := packageName
-	if goos == "windows" {
+	if t.GOOS == "windows" {
 		binaryName += ".exe"
 	}
 
-	// Create platform-specific output directory
-	platformDir := filepath.Join(outputDir, fmt.Sprintf("%s-%s", goos, goarch))
+	// Create target-specific output directory
+	platformDir := filepath.Join(outputDir, t.platformName())
 	if err := os.MkdirAll(platformDir, 0755); err != nil {
 		return "", err
 	}
 
 	outputPath := filepath.Join(platformDir, binaryName)
-	fmt.Printf("Building for %s/%s: %s\n", goos, goarch, outputPath)
+	fmt.Printf("Building for %s: %s\n", t.platformName(), outputPath)
+
+	ldflags := fmt.Sprintf("-X main.Version=%s", version)
+	if len(t.LDFlagsExtra) > 0 {
+		ldflags = strings.Join(append([]string{ldflags}, t.LDFlagsExtra...), " ")
+	}
+
+	args := []string{"build", "-o", outputPath, "-ldflags", ldflags}
+	if len(t.BuildTags) > 0 {
+		args = append(args, "-tags", strings.Join(t.BuildTags, ","))
+	}
 
 	// Set up environment for cross-compilation
-	cmd := exec.Command("go", "build", "-o", outputPath, "-ldflags", fmt.Sprintf("-X main.Version=%s", version))
-	cmd.Env = append(os.Environ(),
-		fmt.Sprintf("GOOS=%s", goos),
-		fmt.Sprintf("GOARCH=%s", goarch),
+	cmd := exec.Command("go", args...)
+	env := append(os.Environ(),
+		fmt.Sprintf("GOOS=%s", t.GOOS),
+		fmt.Sprintf("GOARCH=%s", t.GOARCH),
 	)
+	if t.GOARM != "" {
+		env = append(env, fmt.Sprintf("GOARM=%s", t.GOARM))
+	}
+	if t.CGOEnabled {
+		env = append(env, "CGO_ENABLED=1")
+	} else {
+		env = append(env, "CGO_ENABLED=0")
+	}
+	cmd.Env = env
 
 	// Run the build command
 	output, err := cmd.CombinedOutput()
@@ -157,108 +406,564 @@ func buildBinary(goos, goarch, outputDir, packageName, version string) (string,
 	return outputPath, nil
 }
 
-// createReleaseArchive creates a zip archive for a binary
-func createReleaseArchive(binaryPath, releaseDir, packageName, version string) error {
-	// Get platform info from binary path
-	platformDir := filepath.Dir(binaryPath)
-	platform := filepath.Base(platformDir)
-	
-	// Create zip file name
-	zipName := fmt.Sprintf("%s-%s-%s.zip", packageName, version, platform)
-	zipPath := filepath.Join(releaseDir, zipName)
-	
-	fmt.Printf("Creating release archive: %s\n", zipPath)
-	
-	// Create zip file
-	zipFile, err := os.Create(zipPath)
+// archiveWriter abstracts adding files to a release archive so the zip and
+// tar.gz implementations can share the same file-collection logic.
+type archiveWriter interface {
+	AddFile(name string, mode os.FileMode, r io.Reader) error
+	Close() error
+}
+
+// zipArchiveWriter implements archiveWriter on top of archive/zip.
+type zipArchiveWriter struct {
+	zw *zip.Writer
+}
+
+func newZipArchiveWriter(w io.Writer) *zipArchiveWriter {
+	return &zipArchiveWriter{zw: zip.NewWriter(w)}
+}
+
+func (z *zipArchiveWriter) AddFile(name string, mode os.FileMode, r io.Reader) error {
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate}
+	header.SetMode(mode)
+
+	writer, err := z.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(writer, r)
+	return err
+}
+
+func (z *zipArchiveWriter) Close() error {
+	return z.zw.Close()
+}
+
+// tarGzArchiveWriter implements archiveWriter on top of archive/tar wrapped
+// in compress/gzip, preserving the Unix executable bit.
+type tarGzArchiveWriter struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func newTarGzArchiveWriter(w io.Writer) *tarGzArchiveWriter {
+	gz := gzip.NewWriter(w)
+	return &tarGzArchiveWriter{gz: gz, tw: tar.NewWriter(gz)}
+}
+
+func (t *tarGzArchiveWriter) AddFile(name string, mode os.FileMode, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	header := &tar.Header{
+		Name: name,
+		Mode: int64(mode.Perm()),
+		Size: int64(len(data)),
+	}
+	if err := t.tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err = t.tw.Write(data)
+	return err
+}
+
+func (t *tarGzArchiveWriter) Close() error {
+	if err := t.tw.Close(); err != nil {
+		return err
+	}
+	return t.gz.Close()
+}
+
+// newArchiveWriter returns the archiveWriter for the given format ("zip" or
+// "tar.gz"), writing to w.
+func newArchiveWriter(format string, w io.Writer) (archiveWriter, error) {
+	switch format {
+	case "zip":
+		return newZipArchiveWriter(w), nil
+	case "tar.gz":
+		return newTarGzArchiveWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", format)
+	}
+}
+
+// archiveFormatsFor resolves the -format flag to the concrete archive
+// formats to produce for a given GOOS. In "auto" mode we mirror the
+// convention used by Go's own makerelease/bindist tooling: .zip for
+// Windows, since that's what Explorer handles natively, and .tar.gz
+// everywhere else so the executable bit survives extraction.
+func archiveFormatsFor(goos, format string) []string {
+	switch format {
+	case "zip":
+		return []string{"zip"}
+	case "tar.gz":
+		return []string{"tar.gz"}
+	case "both":
+		return []string{"zip", "tar.gz"}
+	default: // "auto"
+		if goos == "windows" {
+			return []string{"zip"}
+		}
+		return []string{"tar.gz"}
+	}
+}
+
+// checksumEntry records the SHA256 digest of one produced release archive,
+// keyed by its basename within the release directory.
+type checksumEntry struct {
+	name   string
+	sha256 string
+}
+
+// createReleaseArchive creates the release archive(s) for a built target,
+// selecting the format(s) per platform (or as overridden by -format), and
+// returns a checksum entry for each archive produced.
+func createReleaseArchive(result buildResult, releaseDir, packageName, version, format string) ([]checksumEntry, error) {
+	platform := result.target.platformName()
+	binaryName := filepath.Base(result.binaryPath)
+
+	var entries []checksumEntry
+	for _, f := range archiveFormatsFor(result.target.GOOS, format) {
+		archivePath := filepath.Join(releaseDir, fmt.Sprintf("%s-%s-%s.%s", packageName, version, platform, f))
+		fmt.Printf("Creating release archive: %s\n", archivePath)
+
+		digest, err := writeReleaseArchive(archivePath, f, result.binaryPath, binaryName, result.target.ArchiveFiles)
+		if err != nil {
+			return entries, err
+		}
+
+		if err := writeChecksumFile(archivePath, digest); err != nil {
+			fmt.Printf("Warning: could not write checksum file for %s: %v\n", archivePath, err)
+		}
+
+		entries = append(entries, checksumEntry{name: filepath.Base(archivePath), sha256: digest})
+		fmt.Printf("Successfully created %s\n", archivePath)
+	}
+
+	return entries, nil
+}
+
+// writeReleaseArchive writes a single archive of the given format containing
+// the binary (mode 0755), any README.md/config.json/LICENSE (mode 0644), and
+// the target's extra archiveFiles, returning the hex-encoded SHA256 digest of
+// the archive bytes. The digest is computed streamingly via an
+// io.MultiWriter so the archive isn't re-read from disk afterwards.
+func writeReleaseArchive(archivePath, format, binaryPath, binaryName string, extra []archiveFile) (string, error) {
+	out, err := os.Create(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("error creating archive file: %v", err)
+	}
+	defer out.Close()
+
+	hasher := sha256.New()
+	writer, err := newArchiveWriter(format, io.MultiWriter(out, hasher))
+	if err != nil {
+		return "", err
+	}
+
+	if err := addReleaseContents(writer, binaryPath, binaryName, extra); err != nil {
+		writer.Close()
+		return "", err
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// writeChecksumFile writes a per-archive "<hex>  <filename>" digest file
+// consumable by `sha256sum -c`.
+func writeChecksumFile(archivePath, digest string) error {
+	content := fmt.Sprintf("%s  %s\n", digest, filepath.Base(archivePath))
+	return os.WriteFile(archivePath+".sha256", []byte(content), 0644)
+}
+
+// writeChecksumManifest writes the combined SHA256SUMS.txt for every archive
+// produced in this run, in the same "<hex>  <filename>" format.
+func writeChecksumManifest(releaseDir string, entries []checksumEntry) error {
+	var sb strings.Builder
+	for _, e := range entries {
+		sb.WriteString(fmt.Sprintf("%s  %s\n", e.sha256, e.name))
+	}
+	return os.WriteFile(filepath.Join(releaseDir, "SHA256SUMS.txt"), []byte(sb.String()), 0644)
+}
+
+// signArchive invokes `gpg --detach-sign --armor` on path, producing a
+// `.asc` signature file alongside it.
+func signArchive(path string) error {
+	cmd := exec.Command("gpg", "--detach-sign", "--armor", path)
+	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("error creating zip file: %v", err)
-	}
-	defer zipFile.Close()
-	
-	// Create zip writer
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
-	
-	// Add binary to zip
+		return fmt.Errorf("gpg sign failed: %v\n%s", err, output)
+	}
+	return nil
+}
+
+// addReleaseContents adds the binary, the standard companion files, and the
+// target's platform-specific archiveFiles to an archive via the
+// archiveWriter so both zip and tar.gz share this logic.
+func addReleaseContents(w archiveWriter, binaryPath, binaryName string, extra []archiveFile) error {
 	binaryFile, err := os.Open(binaryPath)
 	if err != nil {
 		return fmt.Errorf("error opening binary file: %v", err)
 	}
 	defer binaryFile.Close()
-	
-	// Get file info
-	binaryInfo, err := binaryFile.Stat()
+
+	if err := w.AddFile(binaryName, 0755, binaryFile); err != nil {
+		return fmt.Errorf("error adding binary to archive: %v", err)
+	}
+
+	for _, name := range []string{"README.md", "config.json", "LICENSE"} {
+		if err := addExtraFile(w, name); err != nil {
+			fmt.Printf("Warning: Could not add %s to archive: %v\n", name, err)
+		}
+	}
+
+	for _, af := range extra {
+		if err := addArchiveFile(w, af); err != nil {
+			fmt.Printf("Warning: skipping missing optional file %s: %v\n", af.Src, err)
+		}
+	}
+
+	return nil
+}
+
+// addExtraFile adds a companion file (mode 0644) to the archive.
+func addExtraFile(w archiveWriter, path string) error {
+	file, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("error getting binary file info: %v", err)
+		return err
 	}
-	
-	// Create zip file header
-	header, err := zip.FileInfoHeader(binaryInfo)
+	defer file.Close()
+
+	return w.AddFile(path, 0644, file)
+}
+
+// addArchiveFile adds a target-specific companion file to the archive under
+// af.Dst (or its basename if unset), honoring af.Perm (default 0644).
+func addArchiveFile(w archiveWriter, af archiveFile) error {
+	file, err := os.Open(af.Src)
 	if err != nil {
-		return fmt.Errorf("error creating zip file header: %v", err)
-	}
-	
-	// Set compression
-	header.Method = zip.Deflate
-	
-	// Add file to zip
-	writer, err := zipWriter.CreateHeader(header)
+		return err
+	}
+	defer file.Close()
+
+	name := af.Dst
+	if name == "" {
+		name = filepath.Base(af.Src)
+	}
+
+	perm := af.Perm
+	if perm == 0 {
+		perm = 0644
+	}
+
+	return w.AddFile(name, perm, file)
+}
+
+// ghAPIBase is the GitHub REST API root.
+const ghAPIBase = "https://api.github.com"
+
+// ghRelease is the subset of the GitHub release resource we care about.
+type ghRelease struct {
+	ID        int64     `json:"id"`
+	UploadURL string    `json:"upload_url"`
+	Assets    []ghAsset `json:"assets"`
+}
+
+// ghAsset is the subset of the GitHub release asset resource we care about.
+type ghAsset struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// ghClient is a minimal client for the GitHub Releases REST API.
+type ghClient struct {
+	baseURL    string
+	repo       string
+	token      string
+	httpClient *http.Client
+}
+
+func newGHClient(repo, token string) *ghClient {
+	return &ghClient{baseURL: ghAPIBase, repo: repo, token: token, httpClient: &http.Client{Timeout: 60 * time.Second}}
+}
+
+func (c *ghClient) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	return c.httpClient.Do(req)
+}
+
+// getReleaseByTag looks up an existing release by tag, returning (nil, nil)
+// if no release has that tag yet.
+func (c *ghClient) getReleaseByTag(tag string) (*ghRelease, error) {
+	reqURL := fmt.Sprintf("%s/repos/%s/releases/tags/%s", c.baseURL, c.repo, tag)
+	req, err := http.NewRequest("GET", reqURL, nil)
 	if err != nil {
-		return fmt.Errorf("error creating zip file writer: %v", err)
-	}
-	
-	// Copy binary to zip
-	if _, err := io.Copy(writer, binaryFile); err != nil {
-		return fmt.Errorf("error copying binary to zip: %v", err)
-	}
-	
-	// Add README.md to zip
-	if err := addFileToZip(zipWriter, "README.md"); err != nil {
-		fmt.Printf("Warning: Could not add README.md to zip: %v\n", err)
-	}
-	
-	// Add config.json to zip
-	if err := addFileToZip(zipWriter, "config.json"); err != nil {
-		fmt.Printf("Warning: Could not add config.json to zip: %v\n", err)
-	}
-	
-	// Add LICENSE to zip if it exists
-	if _, err := os.Stat("LICENSE"); err == nil {
-		if err := addFileToZip(zipWriter, "LICENSE"); err != nil {
-			fmt.Printf("Warning: Could not add LICENSE to zip: %v\n", err)
-		}
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, ghAPIError(resp)
+	}
+
+	var release ghRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// createRelease creates a new draft release for tag.
+func (c *ghClient) createRelease(tag, name, body string) (*ghRelease, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"tag_name": tag,
+		"name":     name,
+		"body":     body,
+		"draft":    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reqURL := fmt.Sprintf("%s/repos/%s/releases", c.baseURL, c.repo)
+	req, err := http.NewRequest("POST", reqURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, ghAPIError(resp)
+	}
+
+	var release ghRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// publishRelease flips a release from draft to published.
+func (c *ghClient) publishRelease(id int64) error {
+	payload, err := json.Marshal(map[string]interface{}{"draft": false})
+	if err != nil {
+		return err
+	}
+
+	reqURL := fmt.Sprintf("%s/repos/%s/releases/%d", c.baseURL, c.repo, id)
+	req, err := http.NewRequest("PATCH", reqURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ghAPIError(resp)
 	}
-	
-	fmt.Printf("Successfully created %s\n", zipPath)
 	return nil
 }
 
-// addFileToZip adds a file to a zip archive
-func addFileToZip(zipWriter *zip.Writer, filePath string) error {
-	file, err := os.Open(filePath)
+// deleteAsset deletes a release asset by ID.
+func (c *ghClient) deleteAsset(id int64) error {
+	reqURL := fmt.Sprintf("%s/repos/%s/releases/assets/%d", c.baseURL, c.repo, id)
+	req, err := http.NewRequest("DELETE", reqURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return ghAPIError(resp)
+	}
+	return nil
+}
+
+// uploadAsset uploads path to release's upload_url. If the API reports the
+// asset already exists (422 already_exists), the stale asset is deleted and
+// the upload is retried once.
+func (c *ghClient) uploadAsset(release *ghRelease, path string) error {
+	name := filepath.Base(path)
+	fmt.Printf("Uploading %s\n", name)
+
+	err := c.doUploadAsset(release, path, name)
+	if err == nil {
+		return nil
+	}
+
+	apiErr, ok := err.(*ghAPIErrorResponse)
+	if !ok || !apiErr.isAlreadyExists() {
+		return err
+	}
+
+	assetID, found := findAsset(release.Assets, name)
+	if !found {
+		return err
+	}
+
+	fmt.Printf("Asset %s already exists, replacing it\n", name)
+	if delErr := c.deleteAsset(assetID); delErr != nil {
+		return fmt.Errorf("error deleting existing asset %s: %v", name, delErr)
+	}
+	return c.doUploadAsset(release, path, name)
+}
+
+func (c *ghClient) doUploadAsset(release *ghRelease, path, name string) error {
+	file, err := os.Open(path)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
-	
+
 	info, err := file.Stat()
 	if err != nil {
 		return err
 	}
-	
-	header, err := zip.FileInfoHeader(info)
+
+	req, err := http.NewRequest("POST", uploadURLFor(release.UploadURL, name), file)
 	if err != nil {
 		return err
 	}
-	
-	header.Method = zip.Deflate
-	
-	writer, err := zipWriter.CreateHeader(header)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	// The GitHub upload API rejects chunked transfer encoding, so the
+	// content length must be set explicitly rather than left to Go to infer.
+	req.ContentLength = info.Size()
+
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
-	
-	_, err = io.Copy(writer, file)
-	return err
-}
\ No newline at end of file
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return ghAPIError(resp)
+	}
+	return nil
+}
+
+// uploadURLFor strips the "{?name,label}" URI template suffix from a
+// release's upload_url and appends the asset name as a query parameter.
+func uploadURLFor(rawUploadURL, name string) string {
+	base := rawUploadURL
+	if idx := strings.Index(base, "{"); idx != -1 {
+		base = base[:idx]
+	}
+	return fmt.Sprintf("%s?name=%s", base, url.QueryEscape(name))
+}
+
+func findAsset(assets []ghAsset, name string) (int64, bool) {
+	for _, a := range assets {
+		if a.Name == name {
+			return a.ID, true
+		}
+	}
+	return 0, false
+}
+
+// ghAPIErrorResponse wraps a non-2xx GitHub API response, preserving the
+// status code and body so callers can surface the API's own error message.
+type ghAPIErrorResponse struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ghAPIErrorResponse) Error() string {
+	return fmt.Sprintf("GitHub API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+func (e *ghAPIErrorResponse) isAlreadyExists() bool {
+	return e.StatusCode == http.StatusUnprocessableEntity && strings.Contains(e.Body, "already_exists")
+}
+
+func ghAPIError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	return &ghAPIErrorResponse{StatusCode: resp.StatusCode, Body: string(body)}
+}
+
+// runUpload publishes every file in releaseDir as an asset of the GitHub
+// release tagged tag, creating a draft release first if one doesn't exist.
+func runUpload(releaseDir, repo, token, tag, name string, publish bool) error {
+	if repo == "" {
+		return fmt.Errorf("-github-repo is required")
+	}
+	if tag == "" {
+		return fmt.Errorf("-tag is required")
+	}
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if token == "" {
+		return fmt.Errorf("-github-token or GITHUB_TOKEN must be set")
+	}
+
+	entries, err := os.ReadDir(releaseDir)
+	if err != nil {
+		return fmt.Errorf("error reading release directory %s: %v", releaseDir, err)
+	}
+
+	client := newGHClient(repo, token)
+
+	release, err := client.getReleaseByTag(tag)
+	if err != nil {
+		return fmt.Errorf("error looking up release %s: %v", tag, err)
+	}
+	if release == nil {
+		fmt.Printf("Creating draft release %s\n", tag)
+		release, err = client.createRelease(tag, name, fmt.Sprintf("Release %s", name))
+		if err != nil {
+			return fmt.Errorf("error creating release %s: %v", tag, err)
+		}
+	} else {
+		fmt.Printf("Reusing existing release %s (id %d)\n", tag, release.ID)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := client.uploadAsset(release, filepath.Join(releaseDir, entry.Name())); err != nil {
+			return fmt.Errorf("error uploading %s: %v", entry.Name(), err)
+		}
+	}
+
+	if publish {
+		if err := client.publishRelease(release.ID); err != nil {
+			return fmt.Errorf("error publishing release: %v", err)
+		}
+		fmt.Println("Release published")
+	}
+
+	return nil
+}
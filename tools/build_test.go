@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSelectTargets(t *testing.T) {
+	all := defaultTargets()
+
+	tests := []struct {
+		name    string
+		csv     string
+		want    []string
+		wantErr bool
+	}{
+		{"empty csv selects everything", "", nil, false},
+		{"plain goos/goarch", "darwin/arm64", []string{"darwin-arm64"}, false},
+		{"bare linux/arm excludes goarm variants", "linux/arm", []string{"linux-arm"}, false},
+		{"goarm variant selects only that variant", "linux/arm/6", []string{"linux-arm-6"}, false},
+		{"multiple goarm variants", "linux/arm/6,linux/arm/7", []string{"linux-arm-6", "linux-arm-7"}, false},
+		{"no match is an error", "plan9/amd64", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectTargets(all, tt.csv)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("selectTargets(%q) returned no error, want one", tt.csv)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectTargets(%q): %v", tt.csv, err)
+			}
+
+			if tt.csv == "" {
+				if len(got) != len(all) {
+					t.Fatalf("selectTargets(\"\") returned %d targets, want %d", len(got), len(all))
+				}
+				return
+			}
+
+			var names []string
+			for _, sel := range got {
+				names = append(names, sel.platformName())
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("selectTargets(%q) = %v, want %v", tt.csv, names, tt.want)
+			}
+		})
+	}
+}
+
+func TestArchiveFormatsFor(t *testing.T) {
+	tests := []struct {
+		goos   string
+		format string
+		want   []string
+	}{
+		{"windows", "auto", []string{"zip"}},
+		{"linux", "auto", []string{"tar.gz"}},
+		{"darwin", "auto", []string{"tar.gz"}},
+		{"linux", "zip", []string{"zip"}},
+		{"windows", "tar.gz", []string{"tar.gz"}},
+		{"linux", "both", []string{"zip", "tar.gz"}},
+	}
+
+	for _, tt := range tests {
+		got := archiveFormatsFor(tt.goos, tt.format)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("archiveFormatsFor(%q, %q) = %v, want %v", tt.goos, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestUploadURLFor(t *testing.T) {
+	tests := []struct {
+		name         string
+		rawUploadURL string
+		assetName    string
+		want         string
+	}{
+		{
+			"strips the templated query suffix",
+			"https://uploads.github.com/repos/o/r/releases/1/assets{?name,label}",
+			"filewatcher-linux-amd64.tar.gz",
+			"https://uploads.github.com/repos/o/r/releases/1/assets?name=filewatcher-linux-amd64.tar.gz",
+		},
+		{
+			"escapes special characters in the name",
+			"https://uploads.github.com/repos/o/r/releases/1/assets{?name,label}",
+			"filewatcher checksum.txt",
+			"https://uploads.github.com/repos/o/r/releases/1/assets?name=filewatcher+checksum.txt",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := uploadURLFor(tt.rawUploadURL, tt.assetName); got != tt.want {
+			t.Errorf("uploadURLFor(%q, %q) = %q, want %q", tt.rawUploadURL, tt.assetName, got, tt.want)
+		}
+	}
+}
+
+func TestWriteChecksumFile(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "filewatcher-1.0.0-linux-amd64.tar.gz")
+
+	if err := writeChecksumFile(archivePath, "deadbeef"); err != nil {
+		t.Fatalf("writeChecksumFile: %v", err)
+	}
+
+	got, err := os.ReadFile(archivePath + ".sha256")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	want := "deadbeef  filewatcher-1.0.0-linux-amd64.tar.gz\n"
+	if string(got) != want {
+		t.Errorf("writeChecksumFile content = %q, want %q", got, want)
+	}
+}
+
+func TestGetReleaseByTag(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		wantNil    bool
+		wantErr    bool
+		wantID     int64
+	}{
+		{"existing release", http.StatusOK, `{"id": 42, "upload_url": "https://uploads.example.com/assets"}`, false, false, 42},
+		{"no release for tag", http.StatusNotFound, ``, true, false, 0},
+		{"server error", http.StatusInternalServerError, `{"message": "boom"}`, true, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != "/repos/o/r/releases/tags/v1.0.0" {
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := newGHClient("o/r", "tok")
+			client.baseURL = server.URL
+
+			release, err := client.getReleaseByTag("v1.0.0")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("getReleaseByTag returned no error, want one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getReleaseByTag: %v", err)
+			}
+			if tt.wantNil {
+				if release != nil {
+					t.Fatalf("getReleaseByTag = %+v, want nil", release)
+				}
+				return
+			}
+			if release.ID != tt.wantID {
+				t.Errorf("getReleaseByTag id = %d, want %d", release.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestCreateRelease(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		wantErr    bool
+	}{
+		{"created", http.StatusCreated, `{"id": 7, "upload_url": "https://uploads.example.com/assets"}`, false},
+		{"rejected", http.StatusUnprocessableEntity, `{"message": "validation failed"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/repos/o/r/releases" {
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+				var payload map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&payload)
+				if payload["tag_name"] != "v1.0.0" {
+					t.Errorf("tag_name = %v, want v1.0.0", payload["tag_name"])
+				}
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := newGHClient("o/r", "tok")
+			client.baseURL = server.URL
+
+			release, err := client.createRelease("v1.0.0", "v1.0.0", "Release v1.0.0")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("createRelease returned no error, want one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("createRelease: %v", err)
+			}
+			if release.ID != 7 {
+				t.Errorf("createRelease id = %d, want 7", release.ID)
+			}
+		})
+	}
+}
+
+// TestUploadAssetReplacesExistingAsset exercises the 422-already_exists retry
+// path: the first upload attempt is rejected because the asset already
+// exists, so uploadAsset must delete the stale asset and retry once.
+func TestUploadAssetReplacesExistingAsset(t *testing.T) {
+	dir := t.TempDir()
+	assetPath := filepath.Join(dir, "filewatcher-linux-amd64.tar.gz")
+	if err := os.WriteFile(assetPath, []byte("binary contents"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	var uploadAttempts, deleteCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/assets":
+			uploadAttempts++
+			if uploadAttempts == 1 {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write([]byte(`{"message": "Validation Failed", "errors": [{"code": "already_exists"}]}`))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodDelete && r.URL.Path == "/repos/o/r/releases/assets/99":
+			deleteCalls++
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := newGHClient("o/r", "tok")
+	client.baseURL = server.URL
+	release := &ghRelease{
+		ID:        1,
+		UploadURL: server.URL + "/assets{?name,label}",
+		Assets:    []ghAsset{{ID: 99, Name: "filewatcher-linux-amd64.tar.gz"}},
+	}
+
+	if err := client.uploadAsset(release, assetPath); err != nil {
+		t.Fatalf("uploadAsset: %v", err)
+	}
+	if uploadAttempts != 2 {
+		t.Errorf("uploadAttempts = %d, want 2 (initial + retry after delete)", uploadAttempts)
+	}
+	if deleteCalls != 1 {
+		t.Errorf("deleteCalls = %d, want 1", deleteCalls)
+	}
+}
+
+func TestWriteChecksumManifest(t *testing.T) {
+	dir := t.TempDir()
+	entries := []checksumEntry{
+		{name: "filewatcher-1.0.0-linux-amd64.tar.gz", sha256: "aaaa"},
+		{name: "filewatcher-1.0.0-windows-amd64.zip", sha256: "bbbb"},
+	}
+
+	if err := writeChecksumManifest(dir, entries); err != nil {
+		t.Fatalf("writeChecksumManifest: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "SHA256SUMS.txt"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	want := "aaaa  filewatcher-1.0.0-linux-amd64.tar.gz\n" +
+		"bbbb  filewatcher-1.0.0-windows-amd64.zip\n"
+	if string(got) != want {
+		t.Errorf("SHA256SUMS.txt content = %q, want %q", got, want)
+	}
+}